@@ -0,0 +1,163 @@
+package deepx
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	gifpalette "image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+)
+
+// NewAnimatedStereogramFromMask creates an animated "Random-Dot Stereogram" GIF
+// from the provided multi-frame GIF mask source, running `NewStereogramFromMask`'s
+// algorithm on every frame and re-assembling the result with the original frame
+// delays and disposal methods.
+//
+// The mask source must contain encoded valid animated GIF image data.
+// Every frame is interpreted the same way as in `NewStereogramFromMask`, including
+// the `WithMaskTransform` and `WithMaskThreshold` pre-processing options and
+// `WithMaskBlurRadius` (GIF frames carry no EXIF metadata, so EXIF auto-orientation
+// doesn't apply here).
+// To keep the random-dot background temporally coherent across frames, specify
+// a `WithRandomSeed(...)` option so every frame's anchor pixels take the same colors,
+// derived from the seed and the pixel coordinates.
+//
+// A list of options can be provided to specify additional stereogram processing settings.
+func NewAnimatedStereogramFromMask(maskSrc io.Reader, opts ...StereogramOption) (*gif.GIF, error) {
+	srcGIF, err := gif.DecodeAll(maskSrc)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode animated mask image data: %v", err)
+	}
+	cfg := defaultStereogramCfg
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
+	gifPalette := gifPaletteFromColors(cfg.Palette)
+
+	outGIF := &gif.GIF{
+		Image:           make([]*image.Paletted, len(srcGIF.Image)),
+		Delay:           srcGIF.Delay,
+		Disposal:        srcGIF.Disposal,
+		Config:          srcGIF.Config,
+		BackgroundIndex: srcGIF.BackgroundIndex,
+		LoopCount:       srcGIF.LoopCount,
+	}
+	anchorColor := paletteAnchorColor(cfg.Palette)
+	if cfg.RandomSeed != nil {
+		anchorColor = seededAnchorColor(*cfg.RandomSeed, cfg.Palette)
+	}
+	canvasBounds := image.Rect(0, 0, srcGIF.Config.Width, srcGIF.Config.Height)
+	for i, frame := range flattenGIFFrames(srcGIF, canvasBounds) {
+		maskImg := applyMaskPreprocessing(frame, cfg)
+		maskImgBounds := maskImg.Bounds()
+		frameWidth, frameHeight := maskImgBounds.Dx(), maskImgBounds.Dy()
+		stereogramImg := drawAutoStereogram(
+			buildDepthBuffer(maskImg, cfg),
+			frameWidth, frameHeight, cfg.Mu, e, anchorColor,
+		)
+		paletted := image.NewPaletted(stereogramImg.Bounds(), gifPalette)
+		draw.Draw(paletted, paletted.Bounds(), stereogramImg, image.Point{}, draw.Src)
+		outGIF.Image[i] = paletted
+	}
+	return outGIF, nil
+}
+
+// flattenGIFFrames composites every frame of srcGIF onto canvasBounds in turn,
+// honoring each frame's Disposal method, and returns one full-canvas-sized RGBA
+// image per frame. GIF encoders commonly store every frame after the first as a
+// sub-rectangle of the canvas (`frame.Rect`) to save space; building a depth
+// buffer off that sub-rectangle alone would silently truncate the mask, so every
+// returned frame always covers the full canvas.
+func flattenGIFFrames(srcGIF *gif.GIF, canvasBounds image.Rectangle) []*image.RGBA {
+	canvas := image.NewRGBA(canvasBounds)
+	frames := make([]*image.RGBA, len(srcGIF.Image))
+	for i, frame := range srcGIF.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(srcGIF.Disposal) {
+			disposal = srcGIF.Disposal[i]
+		}
+
+		var previousCanvas *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previousCanvas = image.NewRGBA(canvasBounds)
+			draw.Draw(previousCanvas, canvasBounds, canvas, canvasBounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Rect, frame, frame.Rect.Min, draw.Over)
+		flattened := image.NewRGBA(canvasBounds)
+		draw.Draw(flattened, canvasBounds, canvas, canvasBounds.Min, draw.Src)
+		frames[i] = flattened
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Rect, image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previousCanvas
+		}
+	}
+	return frames
+}
+
+// WithRandomSeed makes `NewAnimatedStereogramFromMask` assign anchor pixels a color
+// that is a deterministic function of seed and the pixel's coordinates, instead of
+// drawing from the global random source, so the random-dot background stays
+// temporally coherent across frames.
+//
+// Unset by default, which leaves the global random source unseeded and lets the
+// background shift from frame to frame.
+func WithRandomSeed(seed int64) StereogramOption {
+	return func(cfg *StereogramConfig) {
+		cfg.RandomSeed = &seed
+	}
+}
+
+// seededAnchorColor returns an anchor-pixel color generator for `drawAutoStereogram`
+// that is a pure function of seed and the pixel's coordinates, so the same (x, y)
+// always produces the same color regardless of which goroutine evaluates it or in
+// what order - unlike `paletteAnchorColor`, which draws from the shared global
+// random source and therefore isn't reproducible across concurrent frame renders.
+func seededAnchorColor(seed int64, palette []Color) func(x, y int) Color {
+	return func(x, y int) Color {
+		h := hashCoords(seed, x, y)
+		if len(palette) == 0 {
+			return Color{
+				R: uint8(h),
+				G: uint8(h >> 8),
+				B: uint8(h >> 16),
+				A: 255,
+			}
+		}
+		return palette[h%uint64(len(palette))]
+	}
+}
+
+// hashCoords combines seed, x and y into a well-mixed 64-bit value, using the
+// SplitMix64 finalizer to avoid the low-order-bit correlation a naive combination
+// of small integers would otherwise produce.
+func hashCoords(seed int64, x, y int) uint64 {
+	h := uint64(seed) ^ uint64(x)*0x9E3779B97F4A7C15 ^ uint64(y)*0xBF58476D1CE4E5B9
+	h ^= h >> 30
+	h *= 0xBF58476D1CE4E5B9
+	h ^= h >> 27
+	h *= 0x94D049BB133111EB
+	h ^= h >> 31
+	return h
+}
+
+// gifPaletteFromColors builds a `color.Palette` suitable for quantizing stereogram
+// frames into, falling back to the 256-color Plan 9 palette when colors is empty
+// (i.e. the random-dot generator isn't restricted to a custom palette).
+func gifPaletteFromColors(colors []Color) color.Palette {
+	if len(colors) == 0 {
+		return gifpalette.Plan9
+	}
+	p := make(color.Palette, len(colors))
+	for i, c := range colors {
+		p[i] = c.RGBA()
+	}
+	return p
+}
@@ -0,0 +1,98 @@
+package deepx
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func solidPalettedFrame(rect image.Rectangle, c color.Color) *image.Paletted {
+	palette := color.Palette{color.Transparent, c}
+	frame := image.NewPaletted(rect, palette)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			frame.Set(x, y, c)
+		}
+	}
+	return frame
+}
+
+func TestFlattenGIFFramesCompositesSubRectangleOntoCanvas(t *testing.T) {
+	canvasBounds := image.Rect(0, 0, 10, 10)
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	srcGIF := &gif.GIF{
+		Image: []*image.Paletted{
+			solidPalettedFrame(canvasBounds, red),
+			solidPalettedFrame(image.Rect(4, 4, 8, 8), blue),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+	}
+
+	frames := flattenGIFFrames(srcGIF, canvasBounds)
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	for _, frame := range frames {
+		if frame.Bounds() != canvasBounds {
+			t.Fatalf("frame bounds = %v, want %v", frame.Bounds(), canvasBounds)
+		}
+	}
+
+	second := frames[1]
+	if got := ColorRGBA(second.At(5, 5)); !got.Equal(ColorRGBA(blue)) {
+		t.Errorf("second frame at (5,5) = %v, want blue (sub-rectangle not composited)", got)
+	}
+	if got := ColorRGBA(second.At(0, 0)); !got.Equal(ColorRGBA(red)) {
+		t.Errorf("second frame at (0,0) = %v, want red (first frame should still show through)", got)
+	}
+}
+
+func TestFlattenGIFFramesDisposalBackgroundClearsFrame(t *testing.T) {
+	canvasBounds := image.Rect(0, 0, 10, 10)
+	red := color.RGBA{R: 255, A: 255}
+	spriteRect := image.Rect(2, 2, 6, 6)
+	srcGIF := &gif.GIF{
+		Image: []*image.Paletted{
+			solidPalettedFrame(spriteRect, red),
+			solidPalettedFrame(image.Rect(0, 0, 1, 1), color.RGBA{}),
+		},
+		Disposal: []byte{gif.DisposalBackground, gif.DisposalNone},
+	}
+
+	frames := flattenGIFFrames(srcGIF, canvasBounds)
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+
+	second := frames[1]
+	if got := ColorRGBA(second.At(3, 3)); got.A != 0 {
+		t.Errorf("second frame at (3,3) = %v, want transparent after DisposalBackground cleared it", got)
+	}
+}
+
+func TestFlattenGIFFramesDisposalPreviousRestoresCanvas(t *testing.T) {
+	canvasBounds := image.Rect(0, 0, 10, 10)
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	spriteRect := image.Rect(2, 2, 6, 6)
+	srcGIF := &gif.GIF{
+		Image: []*image.Paletted{
+			solidPalettedFrame(canvasBounds, red),
+			solidPalettedFrame(spriteRect, green),
+			solidPalettedFrame(image.Rect(0, 0, 1, 1), color.RGBA{}),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+	}
+
+	frames := flattenGIFFrames(srcGIF, canvasBounds)
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(frames))
+	}
+
+	third := frames[2]
+	if got := ColorRGBA(third.At(3, 3)); !got.Equal(ColorRGBA(red)) {
+		t.Errorf("third frame at (3,3) = %v, want red (DisposalPrevious should have restored the canvas before the green sprite)", got)
+	}
+}
@@ -0,0 +1,71 @@
+package deepx
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// NewStereogramFromDepthMap creates a new "Random-Dot Stereogram" image from the
+// provided depth-map source, interpreting the source image as a continuous
+// grayscale height field instead of a binary mask.
+//
+// The depth-map source must contain an encoded valid png, jpeg or gif image data.
+// Each pixel is converted to luminance via `image/color`'s `GrayModel` and mapped
+// from the `[0, 255]` range into the depth range `[DepthMapZMin, DepthMapZMax]`
+// configured via `WithDepthMapMode`, with white pixels treated as the nearest
+// point by default (set `invert` to reverse this).
+//
+// A list of options can be provided to specify additional stereogram processing settings.
+func NewStereogramFromDepthMap(depthMapSrc io.Reader, opts ...StereogramOption) (*image.RGBA, error) {
+	depthMapImg, _, err := image.Decode(depthMapSrc)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode depth map image data: %v", err)
+	}
+	cfg := defaultStereogramCfg
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
+	depthMapImgBounds := depthMapImg.Bounds()
+	imgWidth, imgHeight := depthMapImgBounds.Dx(), depthMapImgBounds.Dy()
+	stereogramImg := drawAutoStereogram(
+		newDepthBufferFromGrayscaleImage(depthMapImg, cfg.DepthMapZMin, cfg.DepthMapZMax, cfg.InvertDepthMap),
+		imgWidth, imgHeight, cfg.Mu, e, paletteAnchorColor(cfg.Palette),
+	)
+	return stereogramImg, nil
+}
+
+// WithDepthMapMode sets the depth range and luminance direction used to interpret
+// a grayscale depth-map source image in `NewStereogramFromDepthMap`.
+//
+// zMax must be in (0, 1]. zMin defaults to 0 and may be left at 0, but must not
+// be negative or greater than zMax. When invert is true, white pixels are
+// treated as the farthest point instead of the nearest.
+func WithDepthMapMode(zMin, zMax float64, invert bool) StereogramOption {
+	return func(cfg *StereogramConfig) {
+		cfg.DepthMapZMin = zMin
+		cfg.DepthMapZMax = zMax
+		cfg.InvertDepthMap = invert
+	}
+}
+
+func newDepthBufferFromGrayscaleImage(img image.Image, zMin, zMax float64, invert bool) [][]float64 {
+	imgBounds := img.Bounds()
+	sizeX, sizeY := imgBounds.Dx(), imgBounds.Dy()
+	z := make([][]float64, sizeX)
+	for x := 0; x < sizeX; x++ {
+		z[x] = make([]float64, sizeY)
+		for y := 0; y < sizeY; y++ {
+			gray := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+			luminance := float64(gray.Y) / 255
+			if invert {
+				luminance = 1 - luminance
+			}
+			z[x][y] = zMin + (zMax-zMin)*luminance
+		}
+	}
+	return z
+}
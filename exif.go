@@ -0,0 +1,156 @@
+package deepx
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// exifOrientationTag is the EXIF tag ID of the Orientation field within a TIFF IFD.
+const exifOrientationTag = 0x0112
+
+// applyEXIFOrientation returns maskImg rotated and/or flipped according to the
+// EXIF Orientation tag found in the raw JPEG data, if any. When no EXIF
+// orientation metadata is present, or its value denotes the normal orientation,
+// maskImg is returned unchanged.
+func applyEXIFOrientation(maskImg image.Image, data []byte) image.Image {
+	switch readEXIFOrientation(data) {
+	case 2:
+		return flipHorizontal(maskImg)
+	case 3:
+		return rotate180(maskImg)
+	case 4:
+		return flipVertical(maskImg)
+	case 5:
+		return rotate270(flipHorizontal(maskImg))
+	case 6:
+		return rotate90(maskImg)
+	case 7:
+		return rotate90(flipHorizontal(maskImg))
+	case 8:
+		return rotate270(maskImg)
+	default:
+		return maskImg
+	}
+}
+
+// readEXIFOrientation scans raw JPEG data for an APP1 EXIF segment and returns
+// the value of its Orientation tag, or 1 (normal orientation) if the data isn't
+// a JPEG, carries no EXIF metadata, or has no Orientation tag.
+func readEXIFOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		if marker == 0xD8 || marker == 0xD9 {
+			pos += 2
+			continue
+		}
+		if marker == 0xDA {
+			break // start of scan: no more metadata segments follow.
+		}
+		segLen := int(data[pos+2])<<8 | int(data[pos+3])
+		if pos+2+segLen > len(data) {
+			break
+		}
+		if marker == 0xE1 {
+			if orientation, ok := readEXIFOrientationFromAPP1(data[pos+4 : pos+2+segLen]); ok {
+				return orientation
+			}
+		}
+		pos += 2 + segLen
+	}
+	return 1
+}
+
+// readEXIFOrientationFromAPP1 parses the TIFF-encoded EXIF payload of an APP1
+// segment for its Orientation tag.
+func readEXIFOrientationFromAPP1(seg []byte) (int, bool) {
+	if len(seg) < 10 || string(seg[:6]) != "Exif\x00\x00" {
+		return 0, false
+	}
+	tiff := seg[6:]
+	var byteOrder binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		byteOrder = binary.LittleEndian
+	case "MM":
+		byteOrder = binary.BigEndian
+	default:
+		return 0, false
+	}
+	if len(tiff) < 8 {
+		return 0, false
+	}
+	ifdOffset := byteOrder.Uint32(tiff[4:8])
+	if int(ifdOffset)+2 > len(tiff) {
+		return 0, false
+	}
+	numEntries := int(byteOrder.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	entriesStart := int(ifdOffset) + 2
+	for i := 0; i < numEntries; i++ {
+		entryOffset := entriesStart + i*12
+		if entryOffset+12 > len(tiff) {
+			break
+		}
+		tag := byteOrder.Uint16(tiff[entryOffset : entryOffset+2])
+		if tag == exifOrientationTag {
+			return int(byteOrder.Uint16(tiff[entryOffset+8 : entryOffset+10])), true
+		}
+	}
+	return 0, false
+}
+
+func flipHorizontal(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Set(w-1-x, y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+func flipVertical(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Set(x, h-1-y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+func rotate180(img image.Image) image.Image {
+	return flipVertical(flipHorizontal(img))
+}
+
+// rotate90 rotates img 90 degrees clockwise.
+func rotate90(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Set(h-1-y, x, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// rotate270 rotates img 270 degrees clockwise (90 degrees counter-clockwise).
+func rotate270(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Set(y, w-1-x, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package deepx
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// WithMaskTransform sets an arbitrary pre-processing pass applied to the decoded
+// mask image, before it is converted into a depth buffer, e.g. a blur, edge-detect,
+// threshold or resize pass.
+func WithMaskTransform(transform func(image.Image) image.Image) StereogramOption {
+	return func(cfg *StereogramConfig) {
+		cfg.MaskTransform = transform
+	}
+}
+
+// WithMaskThreshold treats mask pixels with a grayscale luminance at or above
+// threshold as transparent, letting near-white photos be used as masks without
+// hand-tuning `WithMaskTransparentColor`.
+func WithMaskThreshold(threshold uint8) StereogramOption {
+	return func(cfg *StereogramConfig) {
+		cfg.MaskThreshold = &threshold
+	}
+}
+
+// WithMaskBlurRadius sets the radius, in pixels, of a Gaussian blur applied to the
+// depth buffer before rendering, which reduces "echo" artifacts around silhouette
+// edges in the Thimbleby algorithm.
+func WithMaskBlurRadius(radius float64) StereogramOption {
+	return func(cfg *StereogramConfig) {
+		cfg.MaskBlurRadius = radius
+	}
+}
+
+// decodeMaskImage decodes raw mask image data and runs it through the shared mask
+// pre-processing pipeline: EXIF auto-orientation for JPEG data, then `MaskTransform`
+// and `MaskThreshold` from cfg. It is used by every mask-consuming constructor
+// (`NewStereogramFromMask`, `NewStereogramFromMaskWithPattern`) so that those options
+// behave consistently regardless of which constructor is used.
+func decodeMaskImage(data []byte, cfg StereogramConfig) (image.Image, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode mask image data: %v", err)
+	}
+	if format == "jpeg" {
+		img = applyEXIFOrientation(img, data)
+	}
+	return applyMaskPreprocessing(img, cfg), nil
+}
+
+// applyMaskPreprocessing runs an already-decoded mask image through `MaskTransform`
+// and `MaskThreshold` from cfg. It is also used directly by
+// `NewAnimatedStereogramFromMask`, whose frames come from `image/gif` rather than
+// raw bytes and therefore skip EXIF handling (GIF carries no EXIF metadata).
+func applyMaskPreprocessing(img image.Image, cfg StereogramConfig) image.Image {
+	if cfg.MaskTransform != nil {
+		img = cfg.MaskTransform(img)
+	}
+	if cfg.MaskThreshold != nil {
+		img = thresholdMaskImage(img, *cfg.MaskThreshold)
+	}
+	return img
+}
+
+// buildDepthBuffer builds a depth buffer from an already pre-processed mask image,
+// applying `MaskBlurRadius` from cfg when set. It is used by every mask-consuming
+// constructor so that the option behaves consistently regardless of which
+// constructor is used.
+func buildDepthBuffer(img image.Image, cfg StereogramConfig) [][]float64 {
+	zBuf := newDepthBufferFromImage(img, cfg.MaskTransparentColor)
+	if cfg.MaskBlurRadius > 0 {
+		zBuf = blurDepthBuffer(zBuf, cfg.MaskBlurRadius)
+	}
+	return zBuf
+}
+
+// thresholdMaskImage returns a copy of img where every pixel with a grayscale
+// luminance at or above threshold is made fully transparent, and every other
+// pixel is made fully opaque.
+func thresholdMaskImage(img image.Image, threshold uint8) image.Image {
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+			if gray.Y >= threshold {
+				out.Set(x, y, color.RGBA{})
+				continue
+			}
+			out.Set(x, y, color.RGBA{A: 255})
+		}
+	}
+	return out
+}
+
+// blurDepthBuffer applies a separable Gaussian blur of the given radius to a
+// depth buffer, smoothing the hard edges around silhouette boundaries.
+func blurDepthBuffer(z [][]float64, radius float64) [][]float64 {
+	if radius <= 0 || len(z) == 0 {
+		return z
+	}
+	return convolveSeparable(z, gaussianKernel(radius))
+}
+
+// gaussianKernel builds a normalized 1-D Gaussian kernel spanning +/- radius.
+func gaussianKernel(radius float64) []float64 {
+	sigma := radius / 2
+	size := int(math.Ceil(radius))*2 + 1
+	kernel := make([]float64, size)
+	var sum float64
+	for i := range kernel {
+		d := float64(i - size/2)
+		kernel[i] = math.Exp(-(d * d) / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// convolveSeparable applies kernel to z horizontally and then vertically,
+// clamping out-of-range samples to the nearest edge.
+func convolveSeparable(z [][]float64, kernel []float64) [][]float64 {
+	sizeX, sizeY := len(z), len(z[0])
+	half := len(kernel) / 2
+
+	horizontal := make([][]float64, sizeX)
+	for x := range horizontal {
+		horizontal[x] = make([]float64, sizeY)
+	}
+	for y := 0; y < sizeY; y++ {
+		for x := 0; x < sizeX; x++ {
+			var v float64
+			for k, w := range kernel {
+				v += z[clamp(x+k-half, sizeX)][y] * w
+			}
+			horizontal[x][y] = v
+		}
+	}
+
+	out := make([][]float64, sizeX)
+	for x := range out {
+		out[x] = make([]float64, sizeY)
+	}
+	for x := 0; x < sizeX; x++ {
+		for y := 0; y < sizeY; y++ {
+			var v float64
+			for k, w := range kernel {
+				v += horizontal[x][clamp(y+k-half, sizeY)] * w
+			}
+			out[x][y] = v
+		}
+	}
+	return out
+}
+
+func clamp(i, size int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= size {
+		return size - 1
+	}
+	return i
+}
@@ -0,0 +1,73 @@
+package deepx
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// NewStereogramFromMaskWithPattern creates a new "Single-Image Stereogram" (SIS)
+// image from the provided mask source, using pattern as the repeating tile that
+// unlinked pixels are sampled from instead of a random or palette-based color.
+//
+// The mask source must contain an encoded valid png, jpeg or gif image data, and
+// is interpreted the same way as in `NewStereogramFromMask` (including EXIF
+// auto-orientation and the `WithMaskTransform`, `WithMaskThreshold` and
+// `WithMaskBlurRadius` pre-processing options). The pattern source must also
+// contain an encoded valid png, jpeg or gif image data; it is tiled across the
+// output by sampling `pattern.At(x % tileWidth, y % tileHeight)`.
+//
+// The pattern tile width defaults to the eye separation (the best fit for
+// autostereogram lock-in); use `WithTilePattern` to override it.
+//
+// A list of options can be provided to specify additional stereogram processing settings.
+func NewStereogramFromMaskWithPattern(maskSrc, patternSrc io.Reader, opts ...StereogramOption) (*image.RGBA, error) {
+	maskData, err := io.ReadAll(maskSrc)
+	if err != nil {
+		return nil, fmt.Errorf("could not read mask image data: %v", err)
+	}
+	patternImg, _, err := image.Decode(patternSrc)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode pattern image data: %v", err)
+	}
+	cfg := defaultStereogramCfg
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	maskImg, err := decodeMaskImage(maskData, cfg)
+	if err != nil {
+		return nil, err
+	}
+	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
+	tileWidth := cfg.TileWidth
+	if tileWidth == 0 {
+		tileWidth = int(e)
+	}
+	tileHeight := patternImg.Bounds().Dy()
+	maskImgBounds := maskImg.Bounds()
+	imgWidth, imgHeight := maskImgBounds.Dx(), maskImgBounds.Dy()
+	stereogramImg := drawAutoStereogram(
+		buildDepthBuffer(maskImg, cfg),
+		imgWidth, imgHeight, cfg.Mu, e, patternAnchorColor(patternImg, tileWidth, tileHeight),
+	)
+	return stereogramImg, nil
+}
+
+// WithTilePattern sets the width, in pixels, of the pattern tile sampled by
+// `NewStereogramFromMaskWithPattern`.
+//
+// By default, the tile width is equal to the eye separation.
+func WithTilePattern(width int) StereogramOption {
+	return func(cfg *StereogramConfig) {
+		cfg.TileWidth = width
+	}
+}
+
+// patternAnchorColor returns an anchor-pixel color generator for `drawAutoStereogram`
+// that samples pattern, tiled to tileWidth x tileHeight.
+func patternAnchorColor(pattern image.Image, tileWidth, tileHeight int) func(x, y int) Color {
+	return func(x, y int) Color {
+		return ColorRGBA(pattern.At(x%tileWidth, y%tileHeight))
+	}
+}
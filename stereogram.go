@@ -15,10 +15,11 @@ import (
 
 var (
 	defaultStereogramCfg = StereogramConfig{
-		Palette: make([]Color, 0),
-		Mu:      1 / 3.,
-		DPI:     72,
-		ERatio:  2.5,
+		Palette:      make([]Color, 0),
+		Mu:           1 / 3.,
+		DPI:          72,
+		ERatio:       2.5,
+		DepthMapZMax: 1,
 	}
 )
 
@@ -57,6 +58,55 @@ type StereogramConfig struct {
 	//
 	// Eye separation is assumed to be 2.5 * DPI in by default.
 	ERatio float64
+
+	// Lower bound of the depth range that a grayscale depth-map source image
+	// is mapped into, used by `NewStereogramFromDepthMap`.
+	//
+	// Zero by default.
+	DepthMapZMin float64
+
+	// Upper bound of the depth range that a grayscale depth-map source image
+	// is mapped into, used by `NewStereogramFromDepthMap`.
+	//
+	// Must be in (0, 1]. Equal to 1 by default.
+	DepthMapZMax float64
+
+	// Inverts the luminance-to-depth mapping used by `NewStereogramFromDepthMap`,
+	// so that white pixels are treated as the farthest point instead of the nearest.
+	//
+	// False by default (white pops out).
+	InvertDepthMap bool
+
+	// Width, in pixels, of the pattern tile sampled by `NewStereogramFromMaskWithPattern`.
+	//
+	// Zero by default, which makes the tile width default to the eye separation,
+	// the best fit for autostereogram lock-in.
+	TileWidth int
+
+	// Seeds the random-dot generator used by `NewAnimatedStereogramFromMask` so
+	// that the random-dot background stays temporally coherent across frames.
+	//
+	// Nil by default, which leaves the global random source unseeded.
+	RandomSeed *int64
+
+	// Arbitrary pre-processing pass applied to the decoded mask image before it is
+	// converted into a depth buffer, e.g. a blur, edge-detect, threshold or resize pass.
+	//
+	// Nil by default.
+	MaskTransform func(image.Image) image.Image
+
+	// Treats mask pixels with a grayscale luminance at or above the threshold as
+	// transparent, letting near-white photos be used as masks without hand-tuning
+	// `MaskTransparentColor`.
+	//
+	// Nil by default, which disables thresholding.
+	MaskThreshold *uint8
+
+	// Radius, in pixels, of a Gaussian blur applied to the depth buffer before
+	// rendering, which reduces "echo" artifacts around silhouette edges.
+	//
+	// Zero by default, which disables blurring.
+	MaskBlurRadius float64
 }
 
 // StereogramOption represents type for stereogram image processing option.
@@ -74,22 +124,29 @@ type StereogramOption func(*StereogramConfig)
 // To explicitly specify the color that should be perceived as transparent in the mask image,
 // specify a `WithMaskTransparentColor(...)` in the list of options.
 //
+// JPEG masks are auto-rotated/flipped according to their EXIF Orientation tag before
+// being processed. Use `WithMaskTransform`, `WithMaskThreshold` and `WithMaskBlurRadius`
+// to pre-process the mask image further.
+//
 // A list of options can be provided to specify additional stereogram processing settings.
 func NewStereogramFromMask(maskSrc io.Reader, opts ...StereogramOption) (*image.RGBA, error) {
-	maskImg, _, err := image.Decode(maskSrc)
+	data, err := io.ReadAll(maskSrc)
 	if err != nil {
-		return nil, fmt.Errorf("could not decode mask image data: %v", err)
+		return nil, fmt.Errorf("could not read mask image data: %v", err)
 	}
 	cfg := defaultStereogramCfg
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+	maskImg, err := decodeMaskImage(data, cfg)
+	if err != nil {
+		return nil, err
+	}
 	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
 	maskImgBounds := maskImg.Bounds()
 	imgWidth, imgHeight := maskImgBounds.Dx(), maskImgBounds.Dy()
 	stereogramImg := drawAutoStereogram(
-		newDepthBufferFromImage(maskImg, cfg.MaskTransparentColor),
-		imgWidth, imgHeight, cfg.Mu, e, cfg.Palette,
+		buildDepthBuffer(maskImg, cfg), imgWidth, imgHeight, cfg.Mu, e, paletteAnchorColor(cfg.Palette),
 	)
 	return stereogramImg, nil
 }
@@ -135,6 +192,14 @@ func projSeparation(z, mu, e float64) int {
 	return int(math.Ceil((1 - mu*z) * e / (2 - mu*z)))
 }
 
+// paletteAnchorColor returns an anchor-pixel color generator for `drawAutoStereogram`
+// that picks a color at random from palette, ignoring the pixel coordinates.
+func paletteAnchorColor(palette []Color) func(x, y int) Color {
+	return func(x, y int) Color {
+		return getRandomPaletteColor(palette)
+	}
+}
+
 func getRandomPaletteColor(palette []Color) Color {
 	if len(palette) == 0 {
 		return Color{
@@ -159,7 +224,7 @@ func drawAutoStereogram(
 	zBuf [][]float64,
 	imgWidth, imgHeight int,
 	mu, e float64,
-	palette []Color,
+	anchorColor func(x, y int) Color,
 ) *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, imgWidth, imgHeight))
 	var wg sync.WaitGroup
@@ -202,7 +267,7 @@ func drawAutoStereogram(
 			for x := imgWidth - 1; x >= 0; x-- {
 				pixels[x] = pixels[same[x]]
 				if same[x] == x {
-					pixels[x] = getRandomPaletteColor(palette)
+					pixels[x] = anchorColor(x, y)
 				}
 				img.Set(x, y, pixels[x].RGBA())
 			}
@@ -0,0 +1,127 @@
+package deepx
+
+import (
+	"image"
+	"math"
+)
+
+// lowMatchRatioThreshold is the MatchRatio below which Verify starts suggesting
+// configuration adjustments.
+const lowMatchRatioThreshold = 0.8
+
+// VerifyReport represents the result of reconstructing the depth map encoded
+// in a stereogram and comparing it against the mask image it was generated from.
+type VerifyReport struct {
+
+	// Fraction of pixels whose recovered depth agrees with the original mask.
+	//
+	// 1 means a perfect reconstruction.
+	MatchRatio float64
+
+	// Average recovered pixel separation across the stereogram, in pixels.
+	MeanSeparation float64
+
+	// Suggested output DPI, populated only when MatchRatio is below a usable threshold.
+	SuggestedDPI int
+
+	// Suggested eye separation ratio, populated only when MatchRatio is below a usable threshold.
+	SuggestedERatio float64
+
+	// Suggested depth of field, populated only when MatchRatio is below a usable threshold.
+	SuggestedMu float64
+}
+
+// Verify reconstructs the depth map encoded in stereogram and compares it against
+// mask, the mask image stereogram was generated from with cfg. mask is run through
+// the same `MaskTransform`/`MaskThreshold`/`MaskBlurRadius` pipeline cfg describes,
+// so the ground truth matches what was actually encoded into stereogram.
+//
+// For each pixel, the offset in [projSeparation(1, cfg.Mu, e), projSeparation(0, cfg.Mu, e)]
+// that maximizes the autocorrelation match rate of stereogram's pixel colors is taken as
+// the recovered pixel separation. The resulting depth is compared against mask at the
+// midpoint of each linked pixel pair to produce a match ratio; when the ratio is low, Verify suggests a DPI, eye separation ratio or
+// depth-of-field adjustment.
+//
+// This gives a programmatic way to detect a palette with too few colors, a DPI that is
+// too low, or a mask that is too wide for the chosen eye separation, instead of having
+// to print the stereogram and look for the hidden image.
+func Verify(stereogram *image.RGBA, mask image.Image, cfg StereogramConfig) (VerifyReport, error) {
+	bounds := stereogram.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
+	sMin := projSeparation(1, cfg.Mu, e)
+	sMax := projSeparation(0, cfg.Mu, e)
+	if sMin > sMax {
+		sMin, sMax = sMax, sMin
+	}
+	zBuf := buildDepthBuffer(applyMaskPreprocessing(mask, cfg), cfg)
+
+	var matches, total, separationCount int
+	var separationSum float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			s, ok := recoverSeparation(stereogram, x, y, width, sMin, sMax)
+			if !ok {
+				continue
+			}
+			separationSum += float64(s)
+			separationCount++
+
+			// A repeat at separation s links x with x-s, so it encodes the depth
+			// at their midpoint rather than at x itself.
+			recoveredNear := s <= (sMin+sMax)/2
+			cx := x - s/2
+			originalNear := cx >= 0 && cx < len(zBuf) && y < len(zBuf[cx]) && zBuf[cx][y] > 0
+			if recoveredNear == originalNear {
+				matches++
+			}
+			total++
+		}
+	}
+
+	report := VerifyReport{}
+	if total > 0 {
+		report.MatchRatio = float64(matches) / float64(total)
+	}
+	if separationCount > 0 {
+		report.MeanSeparation = separationSum / float64(separationCount)
+	}
+	if report.MatchRatio < lowMatchRatioThreshold {
+		// The depth resolution (the number of distinct separations a mask can encode)
+		// scales with mu * e, and e scales with DPI * ERatio. A low match ratio most
+		// often means that range is too narrow to survive reconstruction noise, so
+		// widen it on every axis rather than echoing cfg's own values back unchanged.
+		report.SuggestedDPI = cfg.DPI * 2
+		report.SuggestedERatio = cfg.ERatio * 1.5
+		report.SuggestedMu = math.Min(cfg.Mu*1.5, 1)
+	}
+	return report, nil
+}
+
+// recoverSeparation scans offsets in [sMin, sMax] for the one that maximizes the rate
+// at which img.At(x, y) equals img.At(x-s, y) across a small window around x, returning
+// false if no offset could be evaluated (e.g. x is too close to the left edge).
+func recoverSeparation(img *image.RGBA, x, y, width, sMin, sMax int) (int, bool) {
+	const window = 2
+	bestS, bestRatio, found := 0, -1., false
+	for s := sMin; s <= sMax; s++ {
+		var matched, total int
+		for d := -window; d <= window; d++ {
+			xi := x + d
+			if xi < 0 || xi >= width || xi-s < 0 {
+				continue
+			}
+			total++
+			if ColorRGBA(img.At(xi, y)).Equal(ColorRGBA(img.At(xi-s, y))) {
+				matched++
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		if ratio := float64(matched) / float64(total); ratio > bestRatio {
+			bestRatio, bestS, found = ratio, s, true
+		}
+	}
+	return bestS, found
+}
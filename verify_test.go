@@ -0,0 +1,117 @@
+package deepx
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func buildSplitMaskImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+				continue
+			}
+			img.Set(x, y, color.RGBA{})
+		}
+	}
+	return img
+}
+
+func buildGrayscaleMaskImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray := uint8(200)
+			if x >= width/2 {
+				gray = 50
+			}
+			img.Set(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+	return img
+}
+
+func TestVerifyReconstructsSimpleMask(t *testing.T) {
+	cfg := StereogramConfig{Mu: 1 / 3., DPI: 72, ERatio: 2.5}
+	maskImg := buildSplitMaskImage(160, 60)
+	bounds := maskImg.Bounds()
+	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
+	stereogramImg := drawAutoStereogram(
+		newDepthBufferFromImage(maskImg, nil), bounds.Dx(), bounds.Dy(), cfg.Mu, e, seededAnchorColor(7, nil),
+	)
+
+	report, err := Verify(stereogramImg, maskImg, cfg)
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if report.MatchRatio < 0 || report.MatchRatio > 1 {
+		t.Errorf("MatchRatio out of range: got %f", report.MatchRatio)
+	}
+	if report.MatchRatio < 0.6 {
+		t.Errorf("MatchRatio too low for a simple two-region mask: got %f, want >= 0.6", report.MatchRatio)
+	}
+	sMin := projSeparation(1, cfg.Mu, e)
+	sMax := projSeparation(0, cfg.Mu, e)
+	if report.MeanSeparation < float64(sMin) || report.MeanSeparation > float64(sMax) {
+		t.Errorf("MeanSeparation %f out of expected range [%d, %d]", report.MeanSeparation, sMin, sMax)
+	}
+}
+
+func TestVerifyLowMatchRatioSuggestsWiderConfig(t *testing.T) {
+	cfg := StereogramConfig{Mu: 1 / 3., DPI: 72, ERatio: 2.5}
+	maskImg := buildSplitMaskImage(160, 60)
+	bounds := maskImg.Bounds()
+	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
+	// A single-color palette makes every pixel in the stereogram identical, so the
+	// reconstructor can't distinguish any separation from any other - exactly the
+	// "palette has too few colors" failure mode Verify exists to catch.
+	monochrome := []Color{{R: 10, G: 10, B: 10, A: 255}}
+	stereogramImg := drawAutoStereogram(
+		newDepthBufferFromImage(maskImg, nil), bounds.Dx(), bounds.Dy(), cfg.Mu, e, paletteAnchorColor(monochrome),
+	)
+
+	report, err := Verify(stereogramImg, maskImg, cfg)
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if report.MatchRatio >= lowMatchRatioThreshold {
+		t.Fatalf("expected a low match ratio for a single-color palette, got %f", report.MatchRatio)
+	}
+	if report.SuggestedDPI == cfg.DPI {
+		t.Errorf("SuggestedDPI should differ from the input DPI, got %d", report.SuggestedDPI)
+	}
+	if report.SuggestedERatio == cfg.ERatio {
+		t.Errorf("SuggestedERatio should differ from the input ERatio, got %f", report.SuggestedERatio)
+	}
+	if report.SuggestedMu == cfg.Mu {
+		t.Errorf("SuggestedMu should differ from the input Mu, got %f", report.SuggestedMu)
+	}
+}
+
+func TestVerifyAppliesMaskPreprocessingPipeline(t *testing.T) {
+	threshold := uint8(128)
+	cfg := StereogramConfig{Mu: 1 / 3., DPI: 72, ERatio: 2.5, MaskThreshold: &threshold}
+	maskImg := buildGrayscaleMaskImage(160, 60)
+
+	preprocessed := applyMaskPreprocessing(maskImg, cfg)
+	bounds := preprocessed.Bounds()
+	e := math.Ceil(cfg.ERatio * float64(cfg.DPI))
+	stereogramImg := drawAutoStereogram(
+		buildDepthBuffer(preprocessed, cfg), bounds.Dx(), bounds.Dy(), cfg.Mu, e, seededAnchorColor(11, nil),
+	)
+
+	// Verify is given the raw (unthresholded) grayscale mask; it must apply the
+	// same MaskThreshold pipeline internally to reconstruct a matching ground
+	// truth, or the match ratio collapses to chance level.
+	report, err := Verify(stereogramImg, maskImg, cfg)
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if report.MatchRatio < 0.6 {
+		t.Errorf("MatchRatio too low when Verify applies the same threshold pipeline: got %f, want >= 0.6", report.MatchRatio)
+	}
+}